@@ -0,0 +1,44 @@
+package xmpp // import "fluux.io/xmpp"
+
+import (
+	"encoding/xml"
+
+	"fluux.io/xmpp/stanza"
+)
+
+// NSCaps is the XEP-0115 Entity Capabilities namespace.
+const NSCaps = "http://jabber.org/protocol/caps"
+
+// entityCaps is the <c/> XEP-0115 presence extension advertising a caps
+// hash for the sender's identities, features and forms.
+type entityCaps struct {
+	XMLName xml.Name `xml:"http://jabber.org/protocol/caps c"`
+	Hash    string   `xml:"hash,attr"`
+	Node    string   `xml:"node,attr"`
+	Ver     string   `xml:"ver,attr"`
+}
+
+// Namespace makes entityCaps usable as a PresenceExtension.
+func (entityCaps) Namespace() string {
+	return NSCaps
+}
+
+func init() {
+	stanza.TypeRegistry.RegisterPresenceExtension(NSCaps, "c", entityCaps{})
+}
+
+// EntityCaps builds the XEP-0115 <c/> extension advertising disco's caps
+// hash under node. Client.Send and Component.Send attach it automatically
+// to outgoing Presence when their Disco field is set; call EntityCaps
+// directly only when building a Presence to send some other way, e.g.:
+//
+//	p := stanza.NewPresence("", stanza.Attrs{})
+//	p.Extensions = append(p.Extensions, xmpp.EntityCaps("https://fluux.io/xmpp#v1", disco))
+func EntityCaps(node string, disco stanza.DiscoInfo) stanza.PresenceExtension {
+	return entityCaps{
+		XMLName: xml.Name{Space: NSCaps, Local: "c"},
+		Hash:    "sha-1",
+		Node:    node,
+		Ver:     stanza.CapsHash(disco),
+	}
+}
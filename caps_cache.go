@@ -0,0 +1,222 @@
+package xmpp // import "fluux.io/xmpp"
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"fluux.io/xmpp/stanza"
+)
+
+// CapsStore persists resolved XEP-0115 (node, ver) -> DiscoInfo entries so
+// a CapsCache's disco#info lookups survive a process restart.
+type CapsStore interface {
+	Load(ver string) (stanza.DiscoInfo, bool)
+	Save(ver string, info stanza.DiscoInfo)
+}
+
+// FileCapsStore is a CapsStore keeping one JSON file per ver under Dir,
+// named after the hex encoding of ver.
+type FileCapsStore struct {
+	Dir string
+}
+
+// NewFileCapsStore returns a FileCapsStore rooted at dir. dir is created on
+// first Save if it does not already exist.
+func NewFileCapsStore(dir string) *FileCapsStore {
+	return &FileCapsStore{Dir: dir}
+}
+
+// path returns the file holding ver's entry. A base64 ver may contain '/',
+// so ver is hex-encoded to give a single flat file name under Dir.
+func (s *FileCapsStore) path(ver string) string {
+	return filepath.Join(s.Dir, hex.EncodeToString([]byte(ver))+".json")
+}
+
+// Load reads back the entry saved for ver, if any.
+func (s *FileCapsStore) Load(ver string) (stanza.DiscoInfo, bool) {
+	data, err := os.ReadFile(s.path(ver))
+	if err != nil {
+		return stanza.DiscoInfo{}, false
+	}
+	var info stanza.DiscoInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return stanza.DiscoInfo{}, false
+	}
+	return info, true
+}
+
+// Save writes info to disk under ver, overwriting any previous entry.
+func (s *FileCapsStore) Save(ver string, info stanza.DiscoInfo) {
+	data, err := json.Marshal(info)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return
+	}
+	_ = os.WriteFile(s.path(ver), data, 0o644)
+}
+
+// CapsCache intercepts inbound presence carrying a XEP-0115 <c/> caps
+// hash, and issues a single disco#info query per ver it has not already
+// resolved, instead of every handler re-querying disco#info for every
+// contact. Register it on a Router with CapsCache.Register.
+type CapsCache struct {
+	// Store, if set, persists resolved entries so caps survive restarts.
+	Store CapsStore
+
+	mu      sync.RWMutex
+	byVer   map[string]stanza.DiscoInfo
+	byJID   map[string]string // jid -> ver, from the most recent presence seen
+	pending map[string]string // iq id -> ver, while a disco#info query is in flight
+}
+
+// NewCapsCache returns an empty CapsCache. Set Store before use to persist
+// resolved entries on disk.
+func NewCapsCache() *CapsCache {
+	return &CapsCache{
+		byVer:   make(map[string]stanza.DiscoInfo),
+		byJID:   make(map[string]string),
+		pending: make(map[string]string),
+	}
+}
+
+// Register wires the cache into router: it watches incoming presence for
+// caps hashes, and correlates the disco#info queries it sends with their
+// results.
+//
+// Router.Route dispatches a packet to only its first matching route, so
+// Register restricts its presence route to stanzas actually carrying a
+// XEP-0115 <c/> extension, leaving plain presence (subscriptions, MUC,
+// ...) for routes registered elsewhere. Presence that does carry a <c/>
+// is still claimed exclusively: register any other route that needs to
+// see caps-bearing presence before calling Register, the same ordering
+// hazard NewComponent's default routes have.
+func (cc *CapsCache) Register(router *Router) {
+	router.NewRoute().Packet("presence").MatchFunc(cc.hasCaps).HandlerFunc(cc.handlePresence)
+	router.NewRoute().MatchFunc(cc.isPendingResult).HandlerFunc(cc.handleDiscoResult)
+}
+
+// hasCaps reports whether p is a Presence carrying a XEP-0115 <c/>
+// extension.
+func (cc *CapsCache) hasCaps(p Packet) bool {
+	presence, ok := p.(Presence)
+	if !ok {
+		return false
+	}
+	_, found := extractCaps(presence)
+	return found
+}
+
+// extractCaps returns the XEP-0115 <c/> extension carried by presence, if
+// any.
+func extractCaps(presence Presence) (entityCaps, bool) {
+	for _, ext := range presence.Extensions {
+		switch c := ext.(type) {
+		case entityCaps:
+			return c, true
+		case *entityCaps:
+			// Decoded presence holds a pointer: the Presence unmarshaller
+			// allocates registered extensions with reflect.New.
+			return *c, true
+		}
+	}
+	return entityCaps{}, false
+}
+
+// EntityCaps returns the DiscoInfo most recently advertised by jid via
+// XEP-0115 caps, and whether the cache has resolved it yet.
+func (cc *CapsCache) EntityCaps(jid string) (stanza.DiscoInfo, bool) {
+	cc.mu.RLock()
+	defer cc.mu.RUnlock()
+
+	ver, ok := cc.byJID[jid]
+	if !ok {
+		return stanza.DiscoInfo{}, false
+	}
+	info, ok := cc.byVer[ver]
+	return info, ok
+}
+
+func (cc *CapsCache) handlePresence(s Sender, p Packet) {
+	presence, ok := p.(Presence)
+	if !ok {
+		return
+	}
+
+	caps, found := extractCaps(presence)
+	if !found {
+		return
+	}
+
+	iq, err := stanza.NewIQ(stanza.IQTypeGet, stanza.Attrs{To: presence.From})
+	if err != nil {
+		return
+	}
+	iq.AddPayload(&stanza.DiscoInfo{Node: caps.Node + "#" + caps.Ver})
+
+	cc.mu.Lock()
+	cc.byJID[presence.From] = caps.Ver
+	_, known := cc.byVer[caps.Ver]
+	if !known && cc.Store != nil {
+		if info, ok := cc.Store.Load(caps.Ver); ok {
+			cc.byVer[caps.Ver] = info
+			known = true
+		}
+	}
+	alreadyQueried := false
+	for _, ver := range cc.pending {
+		if ver == caps.Ver {
+			alreadyQueried = true
+			break
+		}
+	}
+	if !known && !alreadyQueried {
+		cc.pending[iq.Id] = caps.Ver
+	}
+	cc.mu.Unlock()
+
+	if known || alreadyQueried {
+		return
+	}
+
+	s.Send(iq)
+}
+
+func (cc *CapsCache) isPendingResult(p Packet) bool {
+	iq, ok := p.(IQ)
+	if !ok || iq.Type != stanza.IQTypeResult {
+		return false
+	}
+	cc.mu.RLock()
+	_, ok = cc.pending[iq.Id]
+	cc.mu.RUnlock()
+	return ok
+}
+
+func (cc *CapsCache) handleDiscoResult(s Sender, p Packet) {
+	iq, ok := p.(IQ)
+	if !ok || len(iq.Payload) == 0 {
+		return
+	}
+	disco, ok := iq.Payload[0].(*stanza.DiscoInfo)
+	if !ok {
+		return
+	}
+
+	cc.mu.Lock()
+	ver, ok := cc.pending[iq.Id]
+	if ok {
+		delete(cc.pending, iq.Id)
+		cc.byVer[ver] = *disco
+	}
+	store := cc.Store
+	cc.mu.Unlock()
+
+	if ok && store != nil {
+		store.Save(ver, *disco)
+	}
+}
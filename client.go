@@ -0,0 +1,379 @@
+package xmpp // import "fluux.io/xmpp"
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+
+	"fluux.io/xmpp/stanza"
+)
+
+// Config configures a Client connecting as an end user over SASL and
+// resource binding.
+type Config struct {
+	// Jid is the user's JID, e.g. "user@example.com/resource". The
+	// resource part is optional; the server assigns one when omitted.
+	Jid string
+	// Password authenticates Jid with SASL PLAIN.
+	Password string
+	// Address overrides the server host:port to dial. When empty, it is
+	// derived from the domain part of Jid on the standard XMPP port.
+	Address string
+	// Insecure skips STARTTLS negotiation. Only use this against a
+	// trusted server, e.g. localhost during development.
+	Insecure bool
+	// Retry is accepted for backward compatibility with callers still
+	// setting it; reconnection is StreamManager's job now.
+	Retry int
+	// PacketLogger, if set, receives a copy of every stanza sent.
+	PacketLogger io.Writer
+}
+
+// Client is an XMPP connection authenticated as an end user via SASL and
+// resource binding (RFC 6120). It shares the Sender interface with
+// Component, so the same Router and StreamManager work for both.
+type Client struct {
+	config Config
+	router *Router
+	jid    string // negotiated full JID, once bound
+
+	// Disco, if set, is advertised on every outgoing Presence as a
+	// XEP-0115 <c/> extension computed under node CapsNode, instead of
+	// callers having to remember to call EntityCaps themselves.
+	Disco    *stanza.DiscoInfo
+	CapsNode string
+
+	// Caps, if set, backs the Client's EntityCaps method with a CapsCache
+	// resolving contacts' advertised caps. It is typically the same
+	// CapsCache registered on the Client's Router.
+	Caps *CapsCache
+
+	conn    net.Conn
+	decoder *xml.Decoder
+
+	mu   sync.Mutex
+	done chan struct{}
+}
+
+// NewClient returns a Client dispatching incoming stanzas to router.
+// Connect must be called to open the connection.
+func NewClient(config Config, router *Router) (*Client, error) {
+	if !strings.Contains(config.Jid, "@") {
+		return nil, errors.New("xmpp: client: Jid must be of the form user@domain[/resource]")
+	}
+	return &Client{
+		config: config,
+		router: router,
+		jid:    config.Jid,
+		done:   make(chan struct{}),
+	}, nil
+}
+
+// JID returns the client's full JID, as negotiated by resource binding
+// once Connect has succeeded, or the configured one before that.
+func (c *Client) JID() string {
+	return c.jid
+}
+
+// EntityCaps returns the DiscoInfo most recently resolved for jid via
+// XEP-0115 caps, and whether Caps has resolved it yet. It returns false
+// when Caps is unset, so handlers can call s.(*Client).EntityCaps(jid)
+// without having to thread a *CapsCache through closures themselves.
+func (c *Client) EntityCaps(jid string) (stanza.DiscoInfo, bool) {
+	if c.Caps == nil {
+		return stanza.DiscoInfo{}, false
+	}
+	return c.Caps.EntityCaps(jid)
+}
+
+// Connect dials the server, negotiates STARTTLS (unless Insecure),
+// authenticates with SASL PLAIN, binds a resource and starts dispatching
+// incoming stanzas to the Client's Router. It returns the bind result IQ.
+func (c *Client) Connect() (Packet, error) {
+	domain := c.domain()
+
+	address := c.config.Address
+	if address == "" {
+		address = domain + ":5222"
+	}
+
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	c.conn = conn
+	c.decoder = xml.NewDecoder(conn)
+
+	if err := c.openStream(domain); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if !c.config.Insecure {
+		if err := c.startTLS(domain); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		if err := c.openStream(domain); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	if err := c.authenticate(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if err := c.openStream(domain); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	bindResult, err := c.bindResource()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.done = make(chan struct{})
+	c.mu.Unlock()
+
+	go c.readLoop()
+	return bindResult, nil
+}
+
+// domain returns the domain part of the configured Jid.
+func (c *Client) domain() string {
+	domain := strings.SplitN(c.config.Jid, "@", 2)[1]
+	if i := strings.IndexByte(domain, '/'); i >= 0 {
+		domain = domain[:i]
+	}
+	return domain
+}
+
+// localpart returns the localpart of the configured Jid.
+func (c *Client) localpart() string {
+	return strings.SplitN(c.config.Jid, "@", 2)[0]
+}
+
+// resource returns the resource part of the configured Jid, or "" to let
+// the server assign one during binding.
+func (c *Client) resource() string {
+	i := strings.IndexByte(c.config.Jid, '/')
+	if i < 0 {
+		return ""
+	}
+	return c.config.Jid[i+1:]
+}
+
+// openStream (re)opens the stream to domain and reads until the server's
+// <stream:features/> has been fully consumed, ready for the next
+// negotiation step.
+func (c *Client) openStream(domain string) error {
+	if err := c.SendRaw(fmt.Sprintf(
+		"<?xml version='1.0'?><stream:stream xmlns='jabber:client' xmlns:stream='http://etherx.jabber.org/streams' to='%s' version='1.0'>",
+		domain)); err != nil {
+		return err
+	}
+	return c.skipToElement("features")
+}
+
+// skipToElement consumes tokens until it has fully decoded the named
+// top-level element, discarding its content. It is used for negotiation
+// steps whose content this client does not need to inspect.
+func (c *Client) skipToElement(name string) error {
+	for {
+		t, err := c.decoder.Token()
+		if err != nil {
+			return err
+		}
+		start, ok := t.(xml.StartElement)
+		if !ok || start.Name.Local != name {
+			continue
+		}
+		var discard stanza.Node
+		return c.decoder.DecodeElement(&discard, &start)
+	}
+}
+
+// startTLS negotiates STARTTLS and wraps the connection in TLS.
+func (c *Client) startTLS(domain string) error {
+	if err := c.SendRaw("<starttls xmlns='urn:ietf:params:xml:ns:xmpp-tls'/>"); err != nil {
+		return err
+	}
+
+	t, err := c.decoder.Token()
+	if err != nil {
+		return err
+	}
+	start, ok := t.(xml.StartElement)
+	if !ok || start.Name.Local != "proceed" {
+		return errors.New("xmpp: client: server refused STARTTLS")
+	}
+	if _, err := c.decoder.Token(); err != nil { // consume </proceed>
+		return err
+	}
+
+	tlsConn := tls.Client(c.conn, &tls.Config{ServerName: domain})
+	if err := tlsConn.Handshake(); err != nil {
+		return err
+	}
+	c.conn = tlsConn
+	c.decoder = xml.NewDecoder(tlsConn)
+	return nil
+}
+
+// authenticate performs SASL PLAIN authentication.
+func (c *Client) authenticate() error {
+	payload := "\x00" + c.localpart() + "\x00" + c.config.Password
+	auth := fmt.Sprintf("<auth xmlns='urn:ietf:params:xml:ns:xmpp-sasl' mechanism='PLAIN'>%s</auth>",
+		base64.StdEncoding.EncodeToString([]byte(payload)))
+	if err := c.SendRaw(auth); err != nil {
+		return err
+	}
+
+	t, err := c.decoder.Token()
+	if err != nil {
+		return err
+	}
+	start, ok := t.(xml.StartElement)
+	if !ok {
+		return errors.New("xmpp: client: unexpected SASL reply")
+	}
+
+	switch start.Name.Local {
+	case "success":
+		_, err := c.decoder.Token() // consume </success>
+		return err
+	case "failure":
+		var discard stanza.Node
+		c.decoder.DecodeElement(&discard, &start)
+		return errors.New("xmpp: client: SASL authentication failed")
+	default:
+		return fmt.Errorf("xmpp: client: unexpected SASL reply <%s>", start.Name.Local)
+	}
+}
+
+// bindResource binds the resource part of the configured Jid (or lets the
+// server assign one) and records the resulting full JID.
+func (c *Client) bindResource() (IQ, error) {
+	bindIQ, err := stanza.NewIQ(stanza.IQTypeSet, stanza.Attrs{})
+	if err != nil {
+		return IQ{}, err
+	}
+	bindIQ.AddPayload(&stanza.BindBind{Resource: c.resource()})
+	if err := c.Send(bindIQ); err != nil {
+		return IQ{}, err
+	}
+
+	t, err := c.decoder.Token()
+	if err != nil {
+		return IQ{}, err
+	}
+	start, ok := t.(xml.StartElement)
+	if !ok || start.Name.Local != "iq" {
+		return IQ{}, errors.New("xmpp: client: expected bind result")
+	}
+	var result IQ
+	if err := c.decoder.DecodeElement(&result, &start); err != nil {
+		return IQ{}, err
+	}
+	if result.Type == stanza.IQTypeError {
+		return result, errors.New("xmpp: client: resource binding failed")
+	}
+	for _, payload := range result.Payload {
+		if bind, ok := payload.(*stanza.BindBind); ok {
+			c.jid = bind.Jid
+		}
+	}
+	return result, nil
+}
+
+// readLoop decodes incoming stanzas and dispatches them to the Client's
+// Router until the stream closes.
+func (c *Client) readLoop() {
+	defer close(c.done)
+	for {
+		t, err := c.decoder.Token()
+		if err != nil {
+			return
+		}
+		start, ok := t.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "iq":
+			var iq IQ
+			if err := c.decoder.DecodeElement(&iq, &start); err != nil {
+				return
+			}
+			c.router.Route(c, iq)
+		case "message":
+			var m Message
+			if err := c.decoder.DecodeElement(&m, &start); err != nil {
+				return
+			}
+			c.router.Route(c, m)
+		case "presence":
+			var pr Presence
+			if err := c.decoder.DecodeElement(&pr, &start); err != nil {
+				return
+			}
+			c.router.Route(c, pr)
+		}
+	}
+}
+
+// Resume is a no-op beyond Connect: RFC 6120 has no stream resumption, so
+// Resume just reconnects and re-authenticates from scratch.
+func (c *Client) Resume() (Packet, error) {
+	return c.Connect()
+}
+
+// Disconnect closes the underlying connection.
+func (c *Client) Disconnect() {
+	if c.conn != nil {
+		c.conn.Close()
+	}
+}
+
+// Done returns a channel closed when the client's connection drops.
+func (c *Client) Done() <-chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.done
+}
+
+// Send marshals p and writes it to the stream. Outgoing Presence gets a
+// XEP-0115 caps extension attached automatically when Disco is set.
+func (c *Client) Send(p Packet) error {
+	if presence, ok := p.(Presence); ok && c.Disco != nil {
+		presence.Extensions = append(presence.Extensions, EntityCaps(c.CapsNode, *c.Disco))
+		p = presence
+	}
+
+	data, err := xml.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return c.SendRaw(string(data))
+}
+
+// SendRaw writes s to the stream unmodified.
+func (c *Client) SendRaw(s string) error {
+	if c.config.PacketLogger != nil {
+		fmt.Fprintln(c.config.PacketLogger, "SEND:", s)
+	}
+	_, err := fmt.Fprint(c.conn, s)
+	return err
+}
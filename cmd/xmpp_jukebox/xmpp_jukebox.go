@@ -12,6 +12,7 @@ import (
 	"fluux.io/xmpp"
 	"fluux.io/xmpp/iot"
 	"fluux.io/xmpp/pep"
+	"fluux.io/xmpp/stanza"
 	"github.com/processone/mpg123"
 	"github.com/processone/soundcloud"
 )
@@ -25,69 +26,85 @@ func main() {
 	address := flag.String("address", "", "If needed, XMPP server DNSName or IP and optional port (ie myserver:5222)")
 	flag.Parse()
 
-	var client *xmpp.Client
-	var err error
-	if client, err = connectXmpp(*jid, *password, *address); err != nil {
-		log.Fatal("Could not connect to XMPP: ", err)
-	}
-
 	p, err := mpg123.NewPlayer()
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	// Iterator to receive packets coming from our XMPP connection
-	for packet := range client.Recv() {
+	router := xmpp.NewRouter()
+	router.HandleFunc("message", handlePlay(p))
+	router.NewRoute().IQNamespaces("urn:xmpp:iot:control").HandlerFunc(handleIoT(p))
 
-		switch packet := packet.(type) {
-		case xmpp.Message:
-			processMessage(client, p, &packet)
-		case xmpp.IQ:
-			processIq(client, p, &packet)
-		case xmpp.Presence:
-			// Do nothing with received presence
-		default:
-			fmt.Fprintf(os.Stdout, "Ignoring packet: %T\n", packet)
-		}
+	var client *xmpp.Client
+	if client, err = newXmppClient(*jid, *password, *address, router); err != nil {
+		log.Fatal("Could not create XMPP client: ", err)
+	}
+
+	postConnect := func(s xmpp.Sender) {
+		sendUserTune(s, "Radiohead", "Spectre")
 	}
+
+	sm := xmpp.NewStreamManager(client, postConnect)
+	log.Fatal(sm.Run())
 }
 
-func processMessage(client *xmpp.Client, p *mpg123.Player, packet *xmpp.Message) {
-	command := strings.Trim(packet.Body, " ")
-	if command == "stop" {
-		p.Stop()
-	} else {
-		playSCURL(p, command)
-		sendUserTune(client, "Radiohead", "Spectre")
+func handlePlay(p *mpg123.Player) func(s xmpp.Sender, packet xmpp.Packet) {
+	return func(s xmpp.Sender, packet xmpp.Packet) {
+		message, ok := packet.(xmpp.Message)
+		if !ok {
+			fmt.Fprintf(os.Stdout, "Ignoring packet: %T\n", packet)
+			return
+		}
+
+		command := strings.Trim(message.Body, " ")
+		if command == "stop" {
+			p.Stop()
+		} else {
+			playSCURL(p, command)
+			sendUserTune(s, "Radiohead", "Spectre")
+		}
 	}
 }
 
-func processIq(client *xmpp.Client, p *mpg123.Player, packet *xmpp.IQ) {
-	switch payload := packet.Payload[0].(type) {
-	// We support IOT Control IQ
-	case *iot.ControlSet:
-		var url string
-		for _, element := range payload.Fields {
-			if element.XMLName.Local == "string" && element.Name == "url" {
-				url = strings.Trim(element.Value, " ")
-				break
-			}
+func handleIoT(p *mpg123.Player) func(s xmpp.Sender, packet xmpp.Packet) {
+	return func(s xmpp.Sender, packet xmpp.Packet) {
+		iq, ok := packet.(xmpp.IQ)
+		if !ok {
+			fmt.Fprintf(os.Stdout, "Ignoring packet: %T\n", packet)
+			return
 		}
 
-		playSCURL(p, url)
-		setResponse := new(iot.ControlSetResponse)
-		reply := xmpp.IQ{PacketAttrs: xmpp.PacketAttrs{To: packet.From, Type: "result", Id: packet.Id}, Payload: []xmpp.IQPayload{setResponse}}
-		client.Send(reply)
-		// TODO add Soundclound artist / title retrieval
-		sendUserTune(client, "Radiohead", "Spectre")
-	default:
-		fmt.Fprintf(os.Stdout, "Other IQ Payload: %T\n", packet.Payload)
+		switch payload := iq.Payload[0].(type) {
+		// We support IOT Control IQ
+		case *iot.ControlSet:
+			var url string
+			for _, element := range payload.Fields {
+				if element.XMLName.Local == "string" && element.Name == "url" {
+					url = strings.Trim(element.Value, " ")
+					break
+				}
+			}
+
+			playSCURL(p, url)
+			setResponse := new(iot.ControlSetResponse)
+			reply, err := stanza.NewIQ(stanza.IQTypeResult, stanza.Attrs{To: iq.From, Id: iq.Id})
+			if err != nil {
+				log.Println("could not build IQ reply:", err)
+				return
+			}
+			reply.AddPayload(setResponse)
+			s.Send(reply)
+			// TODO add Soundclound artist / title retrieval
+			sendUserTune(s, "Radiohead", "Spectre")
+		default:
+			fmt.Fprintf(os.Stdout, "Other IQ Payload: %T\n", iq.Payload)
+		}
 	}
 }
 
-func sendUserTune(client *xmpp.Client, artist string, title string) {
+func sendUserTune(s xmpp.Sender, artist string, title string) {
 	tune := pep.Tune{Artist: artist, Title: title}
-	client.SendRaw(tune.XMPPFormat())
+	s.SendRaw(tune.XMPPFormat())
 }
 
 func playSCURL(p *mpg123.Player, rawURL string) {
@@ -98,18 +115,12 @@ func playSCURL(p *mpg123.Player, rawURL string) {
 	p.Play(url)
 }
 
-func connectXmpp(jid string, password string, address string) (client *xmpp.Client, err error) {
+func newXmppClient(jid string, password string, address string, router *xmpp.Router) (client *xmpp.Client, err error) {
 	xmppConfig := xmpp.Config{Address: address,
 		Jid: jid, Password: password, PacketLogger: os.Stdout, Insecure: true,
 		Retry: 10}
 
-	if client, err = xmpp.NewClient(xmppConfig); err != nil {
-		return
-	}
-
-	if _, err = client.Connect(); err != nil {
-		return
-	}
+	client, err = xmpp.NewClient(xmppConfig, router)
 	return
 }
 
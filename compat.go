@@ -0,0 +1,34 @@
+package xmpp // import "fluux.io/xmpp"
+
+import "fluux.io/xmpp/stanza"
+
+// The stanza types below used to live directly in package xmpp. They moved
+// to xmpp/stanza so third-party code can register its own IQ payloads and
+// stanza extensions on stanza.TypeRegistry instead of only the handful
+// wired in by this package's init(). These aliases keep existing code
+// compiling against the old names for one release.
+//
+// Deprecated: use the stanza package directly.
+type (
+	IQ          = stanza.IQ
+	IQPayload   = stanza.IQPayload
+	Err         = stanza.Err
+	Node        = stanza.Node
+	Attr        = stanza.Attr
+	DiscoInfo   = stanza.DiscoInfo
+	DiscoItems  = stanza.DiscoItems
+	Identity    = stanza.Identity
+	Feature     = stanza.Feature
+	DiscoItem   = stanza.DiscoItem
+	BindBind    = stanza.BindBind
+	Message     = stanza.Message
+	Presence    = stanza.Presence
+	// PacketAttrs is the pre-rename name of stanza.Attrs.
+	PacketAttrs = stanza.Attrs
+)
+
+// Deprecated: use the stanza.NS* constants directly.
+const (
+	NSDiscoInfo  = stanza.NSDiscoInfo
+	NSDiscoItems = stanza.NSDiscoItems
+)
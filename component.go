@@ -0,0 +1,293 @@
+package xmpp // import "fluux.io/xmpp"
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"fluux.io/xmpp/stanza"
+)
+
+// componentStreamNS is the namespace used by external components connecting
+// with XEP-0114, as opposed to "jabber:client" used by regular clients.
+const componentStreamNS = "jabber:component:accept"
+
+// ComponentOptions configures a Component connecting to a server as an
+// external XEP-0114 component.
+type ComponentOptions struct {
+	// Domain is the component's own domain, e.g. "jukebox.example.com".
+	Domain string
+	// Secret is the shared secret configured on the server for Domain.
+	Secret string
+	// Address is the server's component port, e.g. "localhost:8888".
+	Address string
+
+	// Name, Category and Type describe the component for the default
+	// jabber:iq:version and disco#info routes registered by NewComponent.
+	Name     string
+	Category string
+	Type     string
+}
+
+// Component is an XMPP connection authenticated with the XEP-0114
+// component handshake instead of a user's SASL bind. It is used to build
+// gateways and services that federate as their own domain. Component
+// shares the Sender interface with Client, so the same Router and
+// StreamManager work for both.
+type Component struct {
+	opts   ComponentOptions
+	router *Router
+
+	// Disco, if set, is advertised on every outgoing Presence as a
+	// XEP-0115 <c/> extension computed under node CapsNode, instead of
+	// callers having to remember to call EntityCaps themselves.
+	Disco    *stanza.DiscoInfo
+	CapsNode string
+
+	// Caps, if set, backs the Component's EntityCaps method with a
+	// CapsCache resolving contacts' advertised caps. It is typically the
+	// same CapsCache registered on the Component's Router.
+	Caps *CapsCache
+
+	conn    net.Conn
+	decoder *xml.Decoder
+
+	mu   sync.Mutex
+	done chan struct{}
+}
+
+// NewComponent returns a Component dispatching incoming stanzas to router.
+// It always registers default "jabber:iq:version" and disco#info routes
+// answering with opts.Name, opts.Category and opts.Type. Because Router
+// dispatches to the first matching route, routes for those namespaces
+// registered on router before NewComponent take precedence over the
+// defaults, and routes registered after it are shadowed by them.
+func NewComponent(opts ComponentOptions, router *Router) *Component {
+	c := &Component{
+		opts:   opts,
+		router: router,
+		done:   make(chan struct{}),
+	}
+	c.registerDefaultRoutes()
+	return c
+}
+
+// EntityCaps returns the DiscoInfo most recently resolved for jid via
+// XEP-0115 caps, and whether Caps has resolved it yet. It returns false
+// when Caps is unset, so handlers can call s.(*Component).EntityCaps(jid)
+// without having to thread a *CapsCache through closures themselves.
+func (c *Component) EntityCaps(jid string) (stanza.DiscoInfo, bool) {
+	if c.Caps == nil {
+		return stanza.DiscoInfo{}, false
+	}
+	return c.Caps.EntityCaps(jid)
+}
+
+func (c *Component) registerDefaultRoutes() {
+	c.router.NewRoute().IQNamespaces("jabber:iq:version").HandlerFunc(c.handleVersion)
+	c.router.NewRoute().IQNamespaces(stanza.NSDiscoInfo).HandlerFunc(c.handleDiscoInfo)
+}
+
+func (c *Component) handleVersion(s Sender, p Packet) {
+	iq, ok := p.(IQ)
+	if !ok {
+		return
+	}
+	reply, err := stanza.NewIQ(stanza.IQTypeResult, stanza.Attrs{To: iq.From, Id: iq.Id})
+	if err != nil {
+		return
+	}
+	reply.AddPayload(&stanza.Version{Name: c.opts.Name})
+	s.Send(reply)
+}
+
+func (c *Component) handleDiscoInfo(s Sender, p Packet) {
+	iq, ok := p.(IQ)
+	if !ok {
+		return
+	}
+	reply, err := stanza.NewIQ(stanza.IQTypeResult, stanza.Attrs{To: iq.From, Id: iq.Id})
+	if err != nil {
+		return
+	}
+	disco := &stanza.DiscoInfo{
+		Identities: []stanza.Identity{{Name: c.opts.Name, Category: c.opts.Category, Type: c.opts.Type}},
+	}
+	reply.AddPayload(disco)
+	s.Send(reply)
+}
+
+// Connect dials opts.Address, opens the XEP-0114 component stream and
+// performs the handshake: the server replies to our stream header with a
+// stream ID, we send <handshake>SHA1(id+secret)</handshake> hex-encoded,
+// and an empty <handshake/> back means we are authenticated.
+func (c *Component) Connect() (Packet, error) {
+	conn, err := net.Dial("tcp", c.opts.Address)
+	if err != nil {
+		return nil, err
+	}
+	c.conn = conn
+	c.decoder = xml.NewDecoder(conn)
+
+	fmt.Fprintf(conn, "<?xml version='1.0'?><stream:stream xmlns='%s' xmlns:stream='http://etherx.jabber.org/streams' to='%s'>",
+		componentStreamNS, c.opts.Domain)
+
+	streamID, err := c.readStreamID()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	handshake := componentHandshake(streamID, c.opts.Secret)
+	if err := c.SendRaw(fmt.Sprintf("<handshake>%s</handshake>", handshake)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if err := c.readHandshakeReply(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.done = make(chan struct{})
+	c.mu.Unlock()
+
+	go c.readLoop()
+	return nil, nil
+}
+
+// componentHandshake computes the XEP-0114 handshake digest: the hex
+// encoding of SHA1(streamID + secret).
+func componentHandshake(streamID, secret string) string {
+	sum := sha1.Sum([]byte(streamID + secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// readStreamID reads the opening <stream:stream> the server sends back and
+// returns its "id" attribute.
+func (c *Component) readStreamID() (string, error) {
+	for {
+		t, err := c.decoder.Token()
+		if err != nil {
+			return "", err
+		}
+		start, ok := t.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if start.Name.Local != "stream" {
+			return "", errors.New("xmpp: component: expected <stream:stream>, got " + start.Name.Local)
+		}
+		for _, attr := range start.Attr {
+			if attr.Name.Local == "id" {
+				return attr.Value, nil
+			}
+		}
+		return "", errors.New("xmpp: component: stream header has no id")
+	}
+}
+
+// readHandshakeReply reads the server's reply to our handshake. An empty
+// <handshake/> means success; anything else, including a stream error,
+// means the secret was rejected.
+func (c *Component) readHandshakeReply() error {
+	for {
+		t, err := c.decoder.Token()
+		if err != nil {
+			return err
+		}
+		switch el := t.(type) {
+		case xml.StartElement:
+			if el.Name.Local != "handshake" {
+				return fmt.Errorf("xmpp: component: handshake rejected, server sent <%s>", el.Name.Local)
+			}
+		case xml.EndElement:
+			if el.Name.Local == "handshake" {
+				return nil
+			}
+		}
+	}
+}
+
+// readLoop decodes incoming stanzas and dispatches them to the Component's
+// Router until the stream closes.
+func (c *Component) readLoop() {
+	defer close(c.done)
+	for {
+		t, err := c.decoder.Token()
+		if err != nil {
+			return
+		}
+		start, ok := t.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "iq":
+			var iq IQ
+			if err := c.decoder.DecodeElement(&iq, &start); err != nil {
+				return
+			}
+			c.router.Route(c, iq)
+		case "message":
+			var m Message
+			if err := c.decoder.DecodeElement(&m, &start); err != nil {
+				return
+			}
+			c.router.Route(c, m)
+		case "presence":
+			var pr Presence
+			if err := c.decoder.DecodeElement(&pr, &start); err != nil {
+				return
+			}
+			c.router.Route(c, pr)
+		}
+	}
+}
+
+// Resume is a no-op for components: XEP-0114 has no stream resumption, so
+// Resume just re-runs the handshake via Connect.
+func (c *Component) Resume() (Packet, error) {
+	return c.Connect()
+}
+
+// Disconnect closes the underlying connection.
+func (c *Component) Disconnect() {
+	if c.conn != nil {
+		c.conn.Close()
+	}
+}
+
+// Done returns a channel closed when the component's connection drops.
+func (c *Component) Done() <-chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.done
+}
+
+// Send marshals p and writes it to the stream. Outgoing Presence gets a
+// XEP-0115 caps extension attached automatically when Disco is set.
+func (c *Component) Send(p Packet) error {
+	if presence, ok := p.(Presence); ok && c.Disco != nil {
+		presence.Extensions = append(presence.Extensions, EntityCaps(c.CapsNode, *c.Disco))
+		p = presence
+	}
+
+	data, err := xml.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return c.SendRaw(string(data))
+}
+
+// SendRaw writes s to the stream unmodified.
+func (c *Component) SendRaw(s string) error {
+	_, err := fmt.Fprint(c.conn, s)
+	return err
+}
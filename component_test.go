@@ -0,0 +1,42 @@
+package xmpp
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestComponentHandshake(t *testing.T) {
+	const want = "23bc343d598f261c64d3115eeea178323e3b745f"
+	if got := componentHandshake("some-stream-id", "s3cr3t"); got != want {
+		t.Errorf("componentHandshake() = %q, want %q", got, want)
+	}
+}
+
+func TestReadStreamID(t *testing.T) {
+	c := &Component{decoder: xml.NewDecoder(strings.NewReader(
+		`<stream:stream xmlns:stream='http://etherx.jabber.org/streams' id='abc123'>`))}
+
+	id, err := c.readStreamID()
+	if err != nil {
+		t.Fatalf("readStreamID() error = %v", err)
+	}
+	if id != "abc123" {
+		t.Errorf("readStreamID() = %q, want %q", id, "abc123")
+	}
+}
+
+func TestReadHandshakeReplySuccess(t *testing.T) {
+	c := &Component{decoder: xml.NewDecoder(strings.NewReader("<handshake/>"))}
+	if err := c.readHandshakeReply(); err != nil {
+		t.Errorf("readHandshakeReply() error = %v, want nil", err)
+	}
+}
+
+func TestReadHandshakeReplyRejected(t *testing.T) {
+	c := &Component{decoder: xml.NewDecoder(strings.NewReader(
+		"<stream:error xmlns:stream='http://etherx.jabber.org/streams'/>"))}
+	if err := c.readHandshakeReply(); err == nil {
+		t.Error("readHandshakeReply() error = nil, want an error for a non-handshake reply")
+	}
+}
@@ -0,0 +1,172 @@
+package xmpp // import "fluux.io/xmpp"
+
+import "sync"
+
+// Packet is implemented by every stanza type (IQ, Message, Presence) that
+// can be sent or received. Name returns the stanza's element name ("iq",
+// "message" or "presence"), which Router uses to dispatch on.
+type Packet interface {
+	Name() string
+}
+
+// Sender is the minimal interface a Handler needs to reply to the stream
+// it was dispatched from. It is deliberately narrow so handlers can be
+// exercised in tests against a fake Sender instead of a live socket.
+type Sender interface {
+	Send(p Packet) error
+	SendRaw(s string) error
+}
+
+// Handler processes a single stanza matched by a Route or registered
+// directly on a Router with HandleFunc.
+type Handler interface {
+	HandlePacket(s Sender, p Packet)
+}
+
+// HandlerFunc is an adapter allowing the use of ordinary functions as
+// Handlers.
+type HandlerFunc func(s Sender, p Packet)
+
+// HandlePacket calls f(s, p).
+func (f HandlerFunc) HandlePacket(s Sender, p Packet) {
+	f(s, p)
+}
+
+// matcher reports whether a packet satisfies a Route condition.
+type matcher func(p Packet) bool
+
+// Route attaches matchers to a Handler. Build one with Router.NewRoute,
+// chain matchers, and terminate with Handler or HandlerFunc to register it.
+type Route struct {
+	router   *Router
+	matchers []matcher
+	handler  Handler
+}
+
+// Packet restricts the route to stanzas with the given element name, e.g.
+// "message", "iq" or "presence".
+func (r *Route) Packet(name string) *Route {
+	r.matchers = append(r.matchers, func(p Packet) bool {
+		return p.Name() == name
+	})
+	return r
+}
+
+// StanzaType restricts the route to stanzas whose "type" attribute equals t.
+func (r *Route) StanzaType(t string) *Route {
+	r.matchers = append(r.matchers, func(p Packet) bool {
+		switch packet := p.(type) {
+		case IQ:
+			return string(packet.Type) == t
+		case Message:
+			return string(packet.Type) == t
+		case Presence:
+			return string(packet.Type) == t
+		}
+		return false
+	})
+	return r
+}
+
+// MatchFunc restricts the route to packets for which fn returns true. It
+// is the escape hatch for conditions the other matchers cannot express,
+// such as correlating an IQ result with a request this process sent.
+func (r *Route) MatchFunc(fn func(p Packet) bool) *Route {
+	r.matchers = append(r.matchers, fn)
+	return r
+}
+
+// IQNamespaces restricts the route to IQ stanzas carrying at least one
+// payload whose namespace is in ns.
+func (r *Route) IQNamespaces(ns ...string) *Route {
+	r.matchers = append(r.matchers, func(p Packet) bool {
+		iq, ok := p.(IQ)
+		if !ok {
+			return false
+		}
+		for _, payload := range iq.Payload {
+			for _, namespace := range ns {
+				if payload.Namespace() == namespace {
+					return true
+				}
+			}
+		}
+		return false
+	})
+	return r
+}
+
+// Handler terminates the route with h and registers it on its Router.
+func (r *Route) Handler(h Handler) *Route {
+	r.handler = h
+	r.router.addRoute(r)
+	return r
+}
+
+// HandlerFunc terminates the route with f and registers it on its Router.
+func (r *Route) HandlerFunc(f func(s Sender, p Packet)) *Route {
+	return r.Handler(HandlerFunc(f))
+}
+
+func (r *Route) match(p Packet) bool {
+	for _, m := range r.matchers {
+		if !m(p) {
+			return false
+		}
+	}
+	return true
+}
+
+// Router dispatches incoming stanzas to the handlers registered on it.
+// Client and Component both hold a Router and feed it every packet they
+// receive, so the same routes work regardless of the underlying stream.
+type Router struct {
+	mu       sync.RWMutex
+	handlers map[string]Handler
+	routes   []*Route
+}
+
+// NewRouter returns an empty Router.
+func NewRouter() *Router {
+	return &Router{handlers: make(map[string]Handler)}
+}
+
+// HandleFunc registers f as the handler for stanzas named name ("message",
+// "iq" or "presence"). It runs only when no route registered with
+// NewRoute matches the packet.
+func (router *Router) HandleFunc(name string, f func(s Sender, p Packet)) {
+	router.mu.Lock()
+	defer router.mu.Unlock()
+	router.handlers[name] = HandlerFunc(f)
+}
+
+// NewRoute starts a chainable Route bound to this router. The route is
+// registered as soon as it is terminated with Handler or HandlerFunc.
+func (router *Router) NewRoute() *Route {
+	return &Route{router: router}
+}
+
+func (router *Router) addRoute(route *Route) {
+	router.mu.Lock()
+	defer router.mu.Unlock()
+	router.routes = append(router.routes, route)
+}
+
+// Route dispatches p to the first matching route, falling back to the
+// handler registered for p.Name() via HandleFunc. Matched handlers run in
+// their own goroutine so a slow handler cannot stall the read loop.
+func (router *Router) Route(s Sender, p Packet) {
+	router.mu.RLock()
+	defer router.mu.RUnlock()
+
+	for _, route := range router.routes {
+		if route.match(p) {
+			go route.handler.HandlePacket(s, p)
+			return
+		}
+	}
+
+	if h, ok := router.handlers[p.Name()]; ok {
+		go h.HandlePacket(s, p)
+	}
+}
@@ -0,0 +1,105 @@
+package xmpp
+
+import (
+	"testing"
+	"time"
+
+	"fluux.io/xmpp/stanza"
+)
+
+type fakeSender struct{}
+
+func (fakeSender) Send(Packet) error    { return nil }
+func (fakeSender) SendRaw(string) error { return nil }
+
+// expectHandled routes p through router and reports whether the handler
+// ran within a short deadline. Router.Route dispatches matched handlers in
+// their own goroutine, so tests signal completion on a channel rather than
+// polling a plain bool.
+func expectHandled(router *Router, p Packet) bool {
+	done := make(chan struct{}, 1)
+	router.Route(signalingSender{done}, p)
+	select {
+	case <-done:
+		return true
+	case <-time.After(time.Second):
+		return false
+	}
+}
+
+// signalingSender is a Sender carrying a channel handlers send on to
+// signal they ran; it is only used to synchronize these tests with
+// Router's asynchronous dispatch.
+type signalingSender struct {
+	done chan struct{}
+}
+
+func (s signalingSender) Send(Packet) error    { return nil }
+func (s signalingSender) SendRaw(string) error { return nil }
+
+func TestRouterRoutesByPacketName(t *testing.T) {
+	router := NewRouter()
+	router.HandleFunc("message", func(s Sender, p Packet) {
+		s.(signalingSender).done <- struct{}{}
+	})
+
+	if !expectHandled(router, stanza.Message{}) {
+		t.Error("expected the \"message\" handler to run for a Message packet")
+	}
+}
+
+func TestRouterIgnoresUnhandledPacket(t *testing.T) {
+	router := NewRouter()
+	router.HandleFunc("message", func(s Sender, p Packet) {
+		s.(signalingSender).done <- struct{}{}
+	})
+
+	if expectHandled(router, stanza.Presence{}) {
+		t.Error("expected no handler to run for a Presence packet when only \"message\" is registered")
+	}
+}
+
+func TestRouteStanzaType(t *testing.T) {
+	router := NewRouter()
+	router.NewRoute().Packet("iq").StanzaType("result").HandlerFunc(func(s Sender, p Packet) {
+		s.(signalingSender).done <- struct{}{}
+	})
+
+	if expectHandled(router, stanza.IQ{Type: stanza.IQTypeGet}) {
+		t.Error("route matched an IQ of type \"get\", want only \"result\"")
+	}
+	if !expectHandled(router, stanza.IQ{Type: stanza.IQTypeResult}) {
+		t.Error("route did not match an IQ of type \"result\"")
+	}
+}
+
+func TestRouteIQNamespaces(t *testing.T) {
+	router := NewRouter()
+	router.NewRoute().IQNamespaces(stanza.NSDiscoInfo).HandlerFunc(func(s Sender, p Packet) {
+		s.(signalingSender).done <- struct{}{}
+	})
+
+	if expectHandled(router, stanza.IQ{Payload: []stanza.IQPayload{&stanza.DiscoItems{}}}) {
+		t.Error("route matched an IQ carrying disco#items, want only disco#info")
+	}
+	if !expectHandled(router, stanza.IQ{Payload: []stanza.IQPayload{&stanza.DiscoInfo{}}}) {
+		t.Error("route did not match an IQ carrying disco#info")
+	}
+}
+
+func TestRouteMatchFunc(t *testing.T) {
+	router := NewRouter()
+	router.NewRoute().MatchFunc(func(p Packet) bool {
+		iq, ok := p.(stanza.IQ)
+		return ok && iq.Id == "wanted"
+	}).HandlerFunc(func(s Sender, p Packet) {
+		s.(signalingSender).done <- struct{}{}
+	})
+
+	if expectHandled(router, stanza.IQ{Attrs: stanza.Attrs{Id: "other"}}) {
+		t.Error("route matched an IQ with the wrong id")
+	}
+	if !expectHandled(router, stanza.IQ{Attrs: stanza.Attrs{Id: "wanted"}}) {
+		t.Error("route did not match an IQ with the expected id")
+	}
+}
@@ -0,0 +1,37 @@
+package stanza // import "fluux.io/xmpp/stanza"
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// Attrs holds the attributes common to IQ, Message and Presence: "id",
+// "to", "from" and "xml:lang". It is the constructor input for NewIQ,
+// NewMessage and NewPresence; each packet's typed "type" attribute is
+// taken separately so it can be checked against the enum valid for that
+// packet kind.
+type Attrs struct {
+	Id   string `xml:"id,attr,omitempty"`
+	From string `xml:"from,attr,omitempty"`
+	To   string `xml:"to,attr,omitempty"`
+	Lang string `xml:"xml:lang,attr,omitempty"`
+}
+
+// withID returns a copy of a with Id set to a freshly generated value if a
+// was missing one.
+func (a Attrs) withID() Attrs {
+	if a.Id == "" {
+		a.Id = newID()
+	}
+	return a
+}
+
+// newID returns a random identifier suitable for the "id" attribute of a
+// stanza that does not specify one.
+func newID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,23 @@
+package stanza // import "fluux.io/xmpp/stanza"
+
+import "encoding/xml"
+
+// NSBind is the namespace used for RFC 6120 resource binding.
+const NSBind = "urn:ietf:params:xml:ns:xmpp-bind"
+
+// BindBind is the IQ payload used to bind a resource to the current
+// session, as part of RFC 6120 stream negotiation.
+type BindBind struct {
+	XMLName  xml.Name `xml:"urn:ietf:params:xml:ns:xmpp-bind bind"`
+	Resource string   `xml:"resource,omitempty"`
+	Jid      string   `xml:"jid,omitempty"`
+}
+
+// Namespace makes BindBind usable as an IQPayload.
+func (BindBind) Namespace() string {
+	return NSBind
+}
+
+func init() {
+	TypeRegistry.RegisterIQPayload(NSBind, "bind", BindBind{})
+}
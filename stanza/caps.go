@@ -0,0 +1,91 @@
+package stanza // import "fluux.io/xmpp/stanza"
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"sort"
+	"strings"
+)
+
+// CapsHash computes the XEP-0115 entity capabilities "ver" string for d:
+// the base64-encoded SHA-1 digest of its identities, features and any
+// XEP-0128 extended forms, each sorted and "<"-terminated per XEP-0115 §5.1.
+func CapsHash(d DiscoInfo) string {
+	var s strings.Builder
+
+	identities := append([]Identity(nil), d.Identities...)
+	sort.Slice(identities, func(i, j int) bool {
+		a, b := identities[i], identities[j]
+		if a.Category != b.Category {
+			return a.Category < b.Category
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.Lang < b.Lang
+	})
+	for _, id := range identities {
+		s.WriteString(id.Category)
+		s.WriteByte('/')
+		s.WriteString(id.Type)
+		s.WriteByte('/')
+		s.WriteString(id.Lang)
+		s.WriteByte('/')
+		s.WriteString(id.Name)
+		s.WriteByte('<')
+	}
+
+	features := make([]string, len(d.Features))
+	for i, f := range d.Features {
+		features[i] = f.Var
+	}
+	sort.Strings(features)
+	for _, f := range features {
+		s.WriteString(f)
+		s.WriteByte('<')
+	}
+
+	forms := append([]DataForm(nil), d.Form...)
+	sort.Slice(forms, func(i, j int) bool {
+		return formType(forms[i]) < formType(forms[j])
+	})
+	for _, form := range forms {
+		s.WriteString(formType(form))
+		s.WriteByte('<')
+
+		fields := make([]Field, 0, len(form.Fields))
+		for _, f := range form.Fields {
+			if f.Var != "FORM_TYPE" {
+				fields = append(fields, f)
+			}
+		}
+		sort.Slice(fields, func(i, j int) bool {
+			return fields[i].Var < fields[j].Var
+		})
+		for _, f := range fields {
+			s.WriteString(f.Var)
+			s.WriteByte('<')
+			values := append([]string(nil), f.Values...)
+			sort.Strings(values)
+			for _, v := range values {
+				s.WriteString(v)
+				s.WriteByte('<')
+			}
+		}
+	}
+
+	digest := sha1.Sum([]byte(s.String()))
+	return base64.StdEncoding.EncodeToString(digest[:])
+}
+
+// formType returns the value of form's hidden FORM_TYPE field, or "" if it
+// has none (which makes the form invalid per XEP-0128, but CapsHash still
+// needs a sort key).
+func formType(form DataForm) string {
+	for _, f := range form.Fields {
+		if f.Var == "FORM_TYPE" && len(f.Values) > 0 {
+			return f.Values[0]
+		}
+	}
+	return ""
+}
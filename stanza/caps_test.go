@@ -0,0 +1,66 @@
+package stanza
+
+import "testing"
+
+// TestCapsHashSimpleExample uses the "Simple Generation Example" from
+// XEP-0115 §5.2 (client "Exodus 0.9.1") to check CapsHash against its
+// published ver string.
+func TestCapsHashSimpleExample(t *testing.T) {
+	disco := DiscoInfo{
+		Identities: []Identity{
+			{Category: "client", Type: "pc", Name: "Exodus 0.9.1"},
+		},
+		Features: []Feature{
+			{Var: "http://jabber.org/protocol/caps"},
+			{Var: "http://jabber.org/protocol/disco#info"},
+			{Var: "http://jabber.org/protocol/disco#items"},
+			{Var: "http://jabber.org/protocol/muc"},
+		},
+	}
+
+	const want = "QgayPKawpkPSDYmwT/WM94uAlu0="
+	if got := CapsHash(disco); got != want {
+		t.Errorf("CapsHash() = %q, want %q", got, want)
+	}
+}
+
+func TestCapsHashSortsFeatures(t *testing.T) {
+	a := DiscoInfo{
+		Identities: []Identity{
+			{Category: "client", Type: "pc", Name: "Exodus 0.9.1"},
+		},
+		Features: []Feature{
+			{Var: "http://jabber.org/protocol/muc"},
+			{Var: "http://jabber.org/protocol/caps"},
+			{Var: "http://jabber.org/protocol/disco#items"},
+			{Var: "http://jabber.org/protocol/disco#info"},
+		},
+	}
+	b := DiscoInfo{
+		Identities: a.Identities,
+		Features: []Feature{
+			{Var: "http://jabber.org/protocol/caps"},
+			{Var: "http://jabber.org/protocol/disco#info"},
+			{Var: "http://jabber.org/protocol/disco#items"},
+			{Var: "http://jabber.org/protocol/muc"},
+		},
+	}
+
+	if CapsHash(a) != CapsHash(b) {
+		t.Error("CapsHash should be independent of feature order")
+	}
+}
+
+func TestCapsHashIncludesExtendedForm(t *testing.T) {
+	var withForm DiscoInfo
+	withForm.AddForm("http://jabber.org/network/serverinfo", Field{
+		Var:    "admin-addresses",
+		Values: []string{"mailto:admin@example.com"},
+	})
+
+	withoutForm := DiscoInfo{}
+
+	if CapsHash(withForm) == CapsHash(withoutForm) {
+		t.Error("CapsHash should change when an extended form is added")
+	}
+}
@@ -0,0 +1,59 @@
+package stanza // import "fluux.io/xmpp/stanza"
+
+import "encoding/xml"
+
+// NSDataForms is the XEP-0004 Data Forms namespace.
+const NSDataForms = "jabber:x:data"
+
+// FormType is the value of a DataForm's "type" attribute.
+type FormType string
+
+const (
+	FormTypeForm   FormType = "form"
+	FormTypeSubmit FormType = "submit"
+	FormTypeResult FormType = "result"
+	FormTypeCancel FormType = "cancel"
+)
+
+// FieldType is the value of a Field's "type" attribute.
+type FieldType string
+
+const (
+	FieldHidden      FieldType = "hidden"
+	FieldTextSingle  FieldType = "text-single"
+	FieldTextPrivate FieldType = "text-private"
+	FieldTextMulti   FieldType = "text-multi"
+	FieldListSingle  FieldType = "list-single"
+	FieldListMulti   FieldType = "list-multi"
+	FieldBoolean     FieldType = "boolean"
+	FieldFixed       FieldType = "fixed"
+	FieldJidSingle   FieldType = "jid-single"
+	FieldJidMulti    FieldType = "jid-multi"
+)
+
+// DataForm implements XEP-0004: Data Forms. It is most often found embedded
+// in a disco#info reply (XEP-0128) or a MUC configuration IQ.
+type DataForm struct {
+	XMLName      xml.Name `xml:"jabber:x:data x"`
+	Type         FormType `xml:"type,attr"`
+	Title        string   `xml:"title,omitempty"`
+	Instructions string   `xml:"instructions,omitempty"`
+	Fields       []Field  `xml:"field"`
+}
+
+// Field is a single field of a DataForm.
+type Field struct {
+	XMLName  xml.Name      `xml:"field"`
+	Var      string        `xml:"var,attr,omitempty"`
+	Type     FieldType     `xml:"type,attr,omitempty"`
+	Label    string        `xml:"label,attr,omitempty"`
+	Required *struct{}     `xml:"required,omitempty"`
+	Values   []string      `xml:"value,omitempty"`
+	Options  []FieldOption `xml:"option,omitempty"`
+}
+
+// FieldOption is one choice of a "list-single" or "list-multi" Field.
+type FieldOption struct {
+	Label string `xml:"label,attr,omitempty"`
+	Value string `xml:"value"`
+}
@@ -0,0 +1,81 @@
+package stanza // import "fluux.io/xmpp/stanza"
+
+import "encoding/xml"
+
+const (
+	NSDiscoInfo  = "http://jabber.org/protocol/disco#info"
+	NSDiscoItems = "http://jabber.org/protocol/disco#items"
+)
+
+// DiscoInfo is the payload of a disco#info reply (XEP-0030). An entity may
+// have more than one Identity (e.g. a MUC room is both a "conference/text"
+// and can double as a bot). Form carries XEP-0128 extended service
+// discovery information, built with AddForm.
+type DiscoInfo struct {
+	XMLName    xml.Name   `xml:"http://jabber.org/protocol/disco#info query"`
+	Node       string     `xml:"node,attr,omitempty"`
+	Identities []Identity `xml:"identity"`
+	Features   []Feature  `xml:"feature"`
+	Form       []DataForm `xml:"jabber:x:data x,omitempty"`
+}
+
+// Namespace makes DiscoInfo usable as an IQPayload.
+func (DiscoInfo) Namespace() string {
+	return NSDiscoInfo
+}
+
+// AddForm appends a XEP-0128 extended service discovery form to d, made up
+// of fields plus the mandatory hidden FORM_TYPE field identifying the form
+// (e.g. "http://jabber.org/network/serverinfo"). Per XEP-0128, a disco#info
+// reply with a FORM_TYPE-less form is invalid, so AddForm fills it in
+// rather than asking every caller to remember it.
+func (d *DiscoInfo) AddForm(formType string, fields ...Field) {
+	formTypeField := Field{
+		Var:    "FORM_TYPE",
+		Type:   FieldHidden,
+		Values: []string{formType},
+	}
+	d.Form = append(d.Form, DataForm{
+		XMLName: xml.Name{Space: NSDataForms, Local: "x"},
+		Type:    FormTypeResult,
+		Fields:  append([]Field{formTypeField}, fields...),
+	})
+}
+
+type Identity struct {
+	XMLName  xml.Name `xml:"identity,omitempty"`
+	Name     string   `xml:"name,attr,omitempty"`
+	Category string   `xml:"category,attr,omitempty"`
+	Type     string   `xml:"type,attr,omitempty"`
+	Lang     string   `xml:"xml:lang,attr,omitempty"`
+}
+
+type Feature struct {
+	XMLName xml.Name `xml:"feature"`
+	Var     string   `xml:"var,attr"`
+}
+
+// ============================================================================
+
+type DiscoItems struct {
+	XMLName xml.Name    `xml:"http://jabber.org/protocol/disco#items query"`
+	Node    string      `xml:"node,attr,omitempty"`
+	Items   []DiscoItem `xml:"item"`
+}
+
+// Namespace makes DiscoItems usable as an IQPayload.
+func (DiscoItems) Namespace() string {
+	return NSDiscoItems
+}
+
+type DiscoItem struct {
+	XMLName xml.Name `xml:"item"`
+	Name    string   `xml:"name,attr,omitempty"`
+	JID     string   `xml:"jid,attr,omitempty"`
+	Node    string   `xml:"node,attr,omitempty"`
+}
+
+func init() {
+	TypeRegistry.RegisterIQPayload(NSDiscoInfo, "query", DiscoInfo{})
+	TypeRegistry.RegisterIQPayload(NSDiscoItems, "query", DiscoItems{})
+}
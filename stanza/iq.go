@@ -0,0 +1,129 @@
+package stanza // import "fluux.io/xmpp/stanza"
+
+import (
+	"encoding/xml"
+	"errors"
+	"reflect"
+)
+
+/*
+TODO support ability to put Raw payload inside IQ
+*/
+
+// ============================================================================
+// IQ Packet
+
+type IQ struct { // Info/Query
+	XMLName xml.Name `xml:"iq"`
+	Attrs
+	Type    IQType      `xml:"type,attr"`
+	Payload []IQPayload `xml:",omitempty"`
+	RawXML  string      `xml:",innerxml"`
+	Error   Err         `xml:"error,omitempty"`
+}
+
+// NewIQ builds an IQ of type t, generating a random Id when attrs did not
+// set one. t is required: unlike Message and Presence, an IQ without a
+// type is not meaningful, so NewIQ returns an error when t is empty.
+func NewIQ(t IQType, attrs Attrs) (IQ, error) {
+	if t == "" {
+		return IQ{}, errors.New("stanza: IQ type is required")
+	}
+	return IQ{
+		XMLName: xml.Name{Local: "iq"},
+		Attrs:   attrs.withID(),
+		Type:    t,
+	}, nil
+}
+
+func (iq *IQ) AddPayload(payload IQPayload) {
+	iq.Payload = append(iq.Payload, payload)
+}
+
+func (iq IQ) MakeError(xerror Err) IQ {
+	from := iq.From
+	to := iq.To
+
+	iq.Type = IQTypeError
+	iq.From = to
+	iq.To = from
+	iq.Error = xerror
+
+	return iq
+}
+
+func (IQ) Name() string {
+	return "iq"
+}
+
+type iqDecoder struct{}
+
+var iq iqDecoder
+
+func (iqDecoder) decode(p *xml.Decoder, se xml.StartElement) (IQ, error) {
+	var packet IQ
+	err := p.DecodeElement(&packet, &se)
+	return packet, err
+}
+
+// UnmarshalXML implements custom parsing for IQs
+func (iq *IQ) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	iq.XMLName = start.Name
+
+	// Extract IQ attributes
+	for _, attr := range start.Attr {
+		if attr.Name.Local == "id" {
+			iq.Id = attr.Value
+		}
+		if attr.Name.Local == "type" {
+			iq.Type = IQType(attr.Value)
+		}
+		if attr.Name.Local == "to" {
+			iq.To = attr.Value
+		}
+		if attr.Name.Local == "from" {
+			iq.From = attr.Value
+		}
+		if attr.Name.Local == "lang" {
+			iq.Lang = attr.Value
+		}
+	}
+
+	// decode inner elements
+	level := 0
+	for {
+		t, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch tt := t.(type) {
+
+		case xml.StartElement:
+			level++
+			if level <= 1 {
+				var elt interface{}
+				if payloadType, ok := TypeRegistry.IQPayloadType(tt.Name.Space, tt.Name.Local); ok {
+					val := reflect.New(payloadType)
+					elt = val.Interface()
+				} else {
+					elt = new(Node)
+				}
+
+				if iqPl, ok := elt.(IQPayload); ok {
+					err = d.DecodeElement(elt, &tt)
+					if err != nil {
+						return err
+					}
+					iq.Payload = append(iq.Payload, iqPl)
+				}
+			}
+
+		case xml.EndElement:
+			level--
+			if tt == start.End() {
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package stanza // import "fluux.io/xmpp/stanza"
+
+import (
+	"encoding/xml"
+	"reflect"
+)
+
+// Message is the stanza used to send text to, or receive text from,
+// another entity. An empty Type is left empty and no "type" attribute is
+// sent; RFC 6121 treats such a message as MessageTypeNormal.
+type Message struct {
+	XMLName xml.Name `xml:"message"`
+	Attrs
+	Type       MessageType        `xml:"type,attr,omitempty"`
+	Subject    string             `xml:"subject,omitempty"`
+	Body       string             `xml:"body,omitempty"`
+	Thread     string             `xml:"thread,omitempty"`
+	Extensions []MessageExtension `xml:",omitempty"`
+	Error      Err                `xml:"error,omitempty"`
+}
+
+// NewMessage builds a Message of type t, generating a random Id when
+// attrs did not set one.
+func NewMessage(t MessageType, attrs Attrs) Message {
+	return Message{
+		XMLName: xml.Name{Local: "message"},
+		Attrs:   attrs.withID(),
+		Type:    t,
+	}
+}
+
+func (Message) Name() string {
+	return "message"
+}
+
+// UnmarshalXML implements custom parsing for Message. encoding/xml cannot
+// populate an interface-typed field on its own, so extension elements are
+// looked up in TypeRegistry the same way IQ looks up its Payload.
+func (m *Message) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	m.XMLName = start.Name
+
+	for _, attr := range start.Attr {
+		switch attr.Name.Local {
+		case "id":
+			m.Id = attr.Value
+		case "type":
+			m.Type = MessageType(attr.Value)
+		case "to":
+			m.To = attr.Value
+		case "from":
+			m.From = attr.Value
+		case "lang":
+			m.Lang = attr.Value
+		}
+	}
+
+	level := 0
+	for {
+		t, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch tt := t.(type) {
+
+		case xml.StartElement:
+			level++
+			if level <= 1 {
+				switch tt.Name.Local {
+				case "body":
+					err = d.DecodeElement(&m.Body, &tt)
+				case "subject":
+					err = d.DecodeElement(&m.Subject, &tt)
+				case "thread":
+					err = d.DecodeElement(&m.Thread, &tt)
+				case "error":
+					err = d.DecodeElement(&m.Error, &tt)
+				default:
+					var elt interface{}
+					if extType, ok := TypeRegistry.MessageExtensionType(tt.Name.Space, tt.Name.Local); ok {
+						elt = reflect.New(extType).Interface()
+					} else {
+						elt = new(Node)
+					}
+
+					if ext, ok := elt.(MessageExtension); ok {
+						err = d.DecodeElement(elt, &tt)
+						if err == nil {
+							m.Extensions = append(m.Extensions, ext)
+						}
+					}
+				}
+				if err != nil {
+					return err
+				}
+			}
+
+		case xml.EndElement:
+			level--
+			if tt == start.End() {
+				return nil
+			}
+		}
+	}
+}
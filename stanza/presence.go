@@ -0,0 +1,105 @@
+package stanza // import "fluux.io/xmpp/stanza"
+
+import (
+	"encoding/xml"
+	"reflect"
+)
+
+// Presence is the stanza used to advertise availability and subscribe to,
+// or share, another entity's availability.
+type Presence struct {
+	XMLName xml.Name `xml:"presence"`
+	Attrs
+	Type       PresenceType        `xml:"type,attr,omitempty"`
+	Show       PresenceShow        `xml:"show,omitempty"`
+	Status     string              `xml:"status,omitempty"`
+	Priority   int8                `xml:"priority,omitempty"`
+	Extensions []PresenceExtension `xml:",omitempty"`
+	Error      Err                 `xml:"error,omitempty"`
+}
+
+// NewPresence builds a Presence of type t, generating a random Id when
+// attrs did not set one. t may be empty: an empty type means the entity
+// is available, which is the most common presence sent.
+func NewPresence(t PresenceType, attrs Attrs) Presence {
+	return Presence{
+		XMLName: xml.Name{Local: "presence"},
+		Attrs:   attrs.withID(),
+		Type:    t,
+	}
+}
+
+func (Presence) Name() string {
+	return "presence"
+}
+
+// UnmarshalXML implements custom parsing for Presence. encoding/xml cannot
+// populate an interface-typed field on its own, so extension elements are
+// looked up in TypeRegistry the same way IQ looks up its Payload.
+func (p *Presence) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	p.XMLName = start.Name
+
+	for _, attr := range start.Attr {
+		switch attr.Name.Local {
+		case "id":
+			p.Id = attr.Value
+		case "type":
+			p.Type = PresenceType(attr.Value)
+		case "to":
+			p.To = attr.Value
+		case "from":
+			p.From = attr.Value
+		case "lang":
+			p.Lang = attr.Value
+		}
+	}
+
+	level := 0
+	for {
+		t, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch tt := t.(type) {
+
+		case xml.StartElement:
+			level++
+			if level <= 1 {
+				switch tt.Name.Local {
+				case "show":
+					err = d.DecodeElement((*string)(&p.Show), &tt)
+				case "status":
+					err = d.DecodeElement(&p.Status, &tt)
+				case "priority":
+					err = d.DecodeElement(&p.Priority, &tt)
+				case "error":
+					err = d.DecodeElement(&p.Error, &tt)
+				default:
+					var elt interface{}
+					if extType, ok := TypeRegistry.PresenceExtensionType(tt.Name.Space, tt.Name.Local); ok {
+						elt = reflect.New(extType).Interface()
+					} else {
+						elt = new(Node)
+					}
+
+					if ext, ok := elt.(PresenceExtension); ok {
+						err = d.DecodeElement(elt, &tt)
+						if err == nil {
+							p.Extensions = append(p.Extensions, ext)
+						}
+					}
+				}
+				if err != nil {
+					return err
+				}
+			}
+
+		case xml.EndElement:
+			level--
+			if tt == start.End() {
+				return nil
+			}
+		}
+	}
+}
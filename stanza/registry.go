@@ -0,0 +1,90 @@
+package stanza // import "fluux.io/xmpp/stanza"
+
+import "reflect"
+
+// IQPayload is implemented by any type that can appear as the payload of
+// an IQ stanza. Namespace identifies it so the IQ unmarshaller can look up
+// the concrete type to decode an incoming payload into.
+type IQPayload interface {
+	Namespace() string
+}
+
+// MessageExtension is implemented by any type that can appear as an
+// extension element of a Message stanza.
+type MessageExtension interface {
+	Namespace() string
+}
+
+// PresenceExtension is implemented by any type that can appear as an
+// extension element of a Presence stanza.
+type PresenceExtension interface {
+	Namespace() string
+}
+
+// Registry maps a stanza child's namespace and local name to the Go type
+// used to decode it. The zero value is not ready to use; call NewRegistry.
+// TypeRegistry is the shared instance consulted by this package's own
+// unmarshallers, and the one third-party packages should register on.
+type Registry struct {
+	iq       map[string]reflect.Type
+	message  map[string]reflect.Type
+	presence map[string]reflect.Type
+}
+
+// TypeRegistry is the registry consulted when parsing incoming stanzas.
+// Packages that define their own IQ payloads or stanza extensions should
+// register them here from an init func, the same way this package
+// registers disco#info, disco#items and resource binding.
+var TypeRegistry = NewRegistry()
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		iq:       make(map[string]reflect.Type),
+		message:  make(map[string]reflect.Type),
+		presence: make(map[string]reflect.Type),
+	}
+}
+
+func regKey(namespace, local string) string {
+	return namespace + " " + local
+}
+
+// RegisterIQPayload records prototype as the type to decode into when an
+// IQ carries a child element named local in namespace.
+func (r *Registry) RegisterIQPayload(namespace, local string, prototype IQPayload) {
+	r.iq[regKey(namespace, local)] = reflect.TypeOf(prototype)
+}
+
+// RegisterMessageExtension records prototype as the type to decode into
+// when a Message carries a child element named local in namespace.
+func (r *Registry) RegisterMessageExtension(namespace, local string, prototype MessageExtension) {
+	r.message[regKey(namespace, local)] = reflect.TypeOf(prototype)
+}
+
+// RegisterPresenceExtension records prototype as the type to decode into
+// when a Presence carries a child element named local in namespace.
+func (r *Registry) RegisterPresenceExtension(namespace, local string, prototype PresenceExtension) {
+	r.presence[regKey(namespace, local)] = reflect.TypeOf(prototype)
+}
+
+// IQPayloadType looks up the type registered for an IQ child named local
+// in namespace.
+func (r *Registry) IQPayloadType(namespace, local string) (reflect.Type, bool) {
+	t, ok := r.iq[regKey(namespace, local)]
+	return t, ok
+}
+
+// MessageExtensionType looks up the type registered for a Message child
+// named local in namespace.
+func (r *Registry) MessageExtensionType(namespace, local string) (reflect.Type, bool) {
+	t, ok := r.message[regKey(namespace, local)]
+	return t, ok
+}
+
+// PresenceExtensionType looks up the type registered for a Presence child
+// named local in namespace.
+func (r *Registry) PresenceExtensionType(namespace, local string) (reflect.Type, bool) {
+	t, ok := r.presence[regKey(namespace, local)]
+	return t, ok
+}
@@ -0,0 +1,48 @@
+package stanza // import "fluux.io/xmpp/stanza"
+
+// IQType is the value of an IQ's "type" attribute, as defined by RFC 6120.
+type IQType string
+
+const (
+	IQTypeGet    IQType = "get"
+	IQTypeSet    IQType = "set"
+	IQTypeResult IQType = "result"
+	IQTypeError  IQType = "error"
+)
+
+// MessageType is the value of a Message's "type" attribute, as defined by
+// RFC 6121.
+type MessageType string
+
+const (
+	MessageTypeNormal    MessageType = "normal"
+	MessageTypeChat      MessageType = "chat"
+	MessageTypeGroupchat MessageType = "groupchat"
+	MessageTypeHeadline  MessageType = "headline"
+	MessageTypeError     MessageType = "error"
+)
+
+// PresenceType is the value of a Presence's "type" attribute. The zero
+// value means the entity is available.
+type PresenceType string
+
+const (
+	PresenceTypeSubscribe    PresenceType = "subscribe"
+	PresenceTypeSubscribed   PresenceType = "subscribed"
+	PresenceTypeUnsubscribe  PresenceType = "unsubscribe"
+	PresenceTypeUnsubscribed PresenceType = "unsubscribed"
+	PresenceTypeUnavailable  PresenceType = "unavailable"
+	PresenceTypeProbe        PresenceType = "probe"
+	PresenceTypeError        PresenceType = "error"
+)
+
+// PresenceShow is the value of a Presence's <show/> child, further
+// qualifying availability.
+type PresenceShow string
+
+const (
+	PresenceShowAway PresenceShow = "away"
+	PresenceShowChat PresenceShow = "chat"
+	PresenceShowDND  PresenceShow = "dnd"
+	PresenceShowXA   PresenceShow = "xa"
+)
@@ -0,0 +1,24 @@
+package stanza // import "fluux.io/xmpp/stanza"
+
+import "encoding/xml"
+
+// NSVersion is the jabber:iq:version namespace (XEP-0092).
+const NSVersion = "jabber:iq:version"
+
+// Version is the IQ payload used to query or report client identification,
+// as defined by XEP-0092.
+type Version struct {
+	XMLName xml.Name `xml:"jabber:iq:version query"`
+	Name    string   `xml:"name,omitempty"`
+	Ver     string   `xml:"version,omitempty"`
+	OS      string   `xml:"os,omitempty"`
+}
+
+// Namespace makes Version usable as an IQPayload.
+func (Version) Namespace() string {
+	return NSVersion
+}
+
+func init() {
+	TypeRegistry.RegisterIQPayload(NSVersion, "query", Version{})
+}
@@ -0,0 +1,123 @@
+package xmpp // import "fluux.io/xmpp"
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	backoffBase = 1 * time.Second
+	backoffCap  = 60 * time.Second
+	stableAfter = 30 * time.Second
+)
+
+// Stream is implemented by Client and Component. It lets StreamManager
+// reconnect either kind of stream with the same backoff and post-connect
+// logic, instead of every caller hand-rolling its own reconnect loop.
+type Stream interface {
+	Sender
+	Connect() (Packet, error)
+	Resume() (Packet, error)
+	Disconnect()
+	// Done returns a channel that is closed when the underlying connection
+	// drops, whether cleanly or not.
+	Done() <-chan struct{}
+}
+
+// StreamManager wraps a Stream with an exponential backoff reconnect loop
+// and runs PostConnect after every successful (re)connect, e.g. to send
+// initial presence or subscribe to PEP nodes.
+type StreamManager struct {
+	stream      Stream
+	PostConnect func(s Sender)
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// NewStreamManager returns a StreamManager driving stream. postConnect may
+// be nil if nothing needs to run after (re)connecting.
+func NewStreamManager(stream Stream, postConnect func(s Sender)) *StreamManager {
+	return &StreamManager{
+		stream:      stream,
+		PostConnect: postConnect,
+		stop:        make(chan struct{}),
+	}
+}
+
+// Run connects the stream and reconnects with exponential backoff and
+// jitter until Stop is called. It only returns once the manager has been
+// stopped.
+func (sm *StreamManager) Run() error {
+	attempt := 0
+	for {
+		select {
+		case <-sm.stop:
+			return nil
+		default:
+		}
+
+		if _, err := sm.stream.Connect(); err != nil {
+			attempt++
+			if sm.sleep(backoff(attempt)) {
+				return nil
+			}
+			continue
+		}
+
+		if sm.PostConnect != nil {
+			sm.PostConnect(sm.stream)
+		}
+
+		connectedAt := time.Now()
+		select {
+		case <-sm.stop:
+			sm.stream.Disconnect()
+			return nil
+		case <-sm.stream.Done():
+		}
+
+		if time.Since(connectedAt) >= stableAfter {
+			attempt = 0
+		} else {
+			attempt++
+		}
+	}
+}
+
+// Start runs the manager in its own goroutine.
+func (sm *StreamManager) Start() {
+	go sm.Run()
+}
+
+// Stop ends the reconnect loop and disconnects the stream. It is safe to
+// call more than once.
+func (sm *StreamManager) Stop() {
+	sm.stopOnce.Do(func() {
+		close(sm.stop)
+	})
+}
+
+// sleep waits for d, or returns true early if the manager is stopped
+// while waiting.
+func (sm *StreamManager) sleep(d time.Duration) bool {
+	select {
+	case <-sm.stop:
+		return true
+	case <-time.After(d):
+		return false
+	}
+}
+
+// backoff returns the delay before reconnect attempt n (1-indexed):
+// min(cap, base*2^n), jittered down by a random factor in [0.5, 1).
+func backoff(attempt int) time.Duration {
+	d := float64(backoffBase) * math.Pow(2, float64(attempt))
+	if d > float64(backoffCap) {
+		d = float64(backoffCap)
+	}
+	jittered := d * (0.5 + rand.Float64()*0.5)
+	return time.Duration(jittered)
+}
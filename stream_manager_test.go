@@ -0,0 +1,40 @@
+package xmpp
+
+import (
+	"testing"
+	"time"
+)
+
+// TestBackoffIsWithinJitterBounds checks backoff(attempt) against bounds
+// derived directly from the spec (base*2^attempt, capped, then scaled by
+// a jitter factor between 0.5 and 1)
+// rather than recomputing the same formula under test, so a wrong
+// exponent or base would actually be caught.
+func TestBackoffIsWithinJitterBounds(t *testing.T) {
+	cases := []struct {
+		attempt          int
+		wantMin, wantMax time.Duration
+	}{
+		{attempt: 1, wantMin: 1 * time.Second, wantMax: 2 * time.Second},
+		{attempt: 2, wantMin: 2 * time.Second, wantMax: 4 * time.Second},
+		{attempt: 3, wantMin: 4 * time.Second, wantMax: 8 * time.Second},
+		{attempt: 6, wantMin: 30 * time.Second, wantMax: 60 * time.Second}, // base*2^6 = 64s, capped to 60s
+	}
+
+	for _, c := range cases {
+		for i := 0; i < 20; i++ {
+			d := backoff(c.attempt)
+			if d < c.wantMin || d > c.wantMax {
+				t.Fatalf("backoff(%d) = %v, want within [%v, %v]", c.attempt, d, c.wantMin, c.wantMax)
+			}
+		}
+	}
+}
+
+func TestBackoffNeverExceedsCap(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		if d := backoff(30); d > backoffCap {
+			t.Fatalf("backoff(30) = %v, want <= cap %v", d, backoffCap)
+		}
+	}
+}